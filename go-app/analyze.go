@@ -0,0 +1,279 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Analyzer computes one kind of text analysis over a sentence. Result is
+// whatever JSON-marshalable value best represents that analysis (an int,
+// a map, a slice of token counts, ...).
+type Analyzer interface {
+	Name() string
+	Analyze(sentence string) interface{}
+}
+
+// analyzers is the registry of available Analyzers, keyed by name. New
+// analyzers register themselves in init() and need no changes to the HTTP
+// layer.
+var analyzers = make(map[string]Analyzer)
+
+func registerAnalyzer(a Analyzer) {
+	analyzers[a.Name()] = a
+}
+
+func init() {
+	registerAnalyzer(wordsAnalyzer{})
+	registerAnalyzer(vowelsAnalyzer{})
+	registerAnalyzer(sentencesAnalyzer{})
+	registerAnalyzer(syllablesAnalyzer{})
+	registerAnalyzer(avgWordLengthAnalyzer{})
+	registerAnalyzer(freqAnalyzer{topN: 5})
+	registerAnalyzer(languageAnalyzer{})
+}
+
+// RunAnalyzers runs each named analyzer against sentence and returns a
+// map keyed by analyzer name. An unrecognized name reports an error
+// value rather than being silently dropped, so callers can tell a typo
+// apart from a genuinely empty result.
+func RunAnalyzers(sentence string, names []string) map[string]interface{} {
+	results := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		a, ok := analyzers[name]
+		if !ok {
+			results[name] = map[string]string{"error": "unknown analyzer"}
+			continue
+		}
+		results[name] = a.Analyze(sentence)
+	}
+	return results
+}
+
+// wordsAnalyzer counts whitespace-delimited words.
+type wordsAnalyzer struct{}
+
+func (wordsAnalyzer) Name() string { return "words" }
+
+func (wordsAnalyzer) Analyze(sentence string) interface{} {
+	return len(strings.Fields(sentence))
+}
+
+// vowelRunes are the base (lowercase, decomposed-free) vowel letters this
+// service recognizes across Latin, Greek and Cyrillic scripts, including
+// common precomposed Latin vowels with diacritics.
+var vowelRunes = []rune{
+	'a', 'e', 'i', 'o', 'u',
+	'á', 'à', 'â', 'ä', 'ã', 'å', 'ā',
+	'é', 'è', 'ê', 'ë', 'ē',
+	'í', 'ì', 'î', 'ï', 'ī',
+	'ó', 'ò', 'ô', 'ö', 'õ', 'ō', 'ø',
+	'ú', 'ù', 'û', 'ü', 'ū',
+	'α', 'ε', 'η', 'ι', 'ο', 'υ', 'ω',
+	'а', 'е', 'ё', 'и', 'і', 'о', 'у', 'ы', 'э', 'ю', 'я',
+}
+
+var vowelSet = buildVowelSet()
+
+func buildVowelSet() map[rune]struct{} {
+	set := make(map[rune]struct{}, len(vowelRunes))
+	for _, r := range vowelRunes {
+		set[r] = struct{}{}
+	}
+	return set
+}
+
+// isVowel reports whether r is one of the recognized vowel letters for
+// any supported script, case-insensitively.
+func isVowel(r rune) bool {
+	_, ok := vowelSet[unicode.ToLower(r)]
+	return ok
+}
+
+// vowelsAnalyzer counts vowels and consonants. Combining diacritical
+// marks (unicode.Mn, e.g. a standalone U+0301 COMBINING ACUTE ACCENT)
+// are skipped rather than counted as their own letter, since they modify
+// the preceding base vowel rather than adding one.
+type vowelsAnalyzer struct{}
+
+func (vowelsAnalyzer) Name() string { return "vowels" }
+
+func (vowelsAnalyzer) Analyze(sentence string) interface{} {
+	var vowels, consonants int
+	for _, r := range sentence {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if isVowel(r) {
+			vowels++
+		} else {
+			consonants++
+		}
+	}
+	return map[string]int{"vowels": vowels, "consonants": consonants}
+}
+
+// sentenceTerminators are the punctuation runes, across several scripts,
+// that end a sentence.
+var sentenceTerminators = map[rune]struct{}{
+	'.': {}, '!': {}, '?': {}, '…': {},
+	'。': {}, '！': {}, '？': {},
+}
+
+// sentencesAnalyzer counts sentences by counting runs of terminal
+// punctuation (so "Really?!" and "Wait..." each count as one sentence
+// end, not two or three).
+type sentencesAnalyzer struct{}
+
+func (sentencesAnalyzer) Name() string { return "sentences" }
+
+func (sentencesAnalyzer) Analyze(sentence string) interface{} {
+	count := 0
+	inTerminator := false
+	for _, r := range sentence {
+		_, terminator := sentenceTerminators[r]
+		terminator = terminator && unicode.IsPunct(r)
+		if terminator {
+			if !inTerminator {
+				count++
+			}
+		}
+		inTerminator = terminator
+	}
+	return count
+}
+
+// syllablesAnalyzer estimates syllable count per word by counting runs
+// of vowel letters, with the classic adjustment for a silent trailing
+// "e". It's a heuristic, not a dictionary lookup.
+type syllablesAnalyzer struct{}
+
+func (syllablesAnalyzer) Name() string { return "syllables" }
+
+func (syllablesAnalyzer) Analyze(sentence string) interface{} {
+	total := 0
+	for _, word := range strings.Fields(sentence) {
+		total += estimateSyllables(word)
+	}
+	return total
+}
+
+func estimateSyllables(word string) int {
+	runes := []rune(strings.ToLower(word))
+	count := 0
+	prevVowel := false
+	for _, r := range runes {
+		v := unicode.IsLetter(r) && isVowel(r)
+		if v && !prevVowel {
+			count++
+		}
+		prevVowel = v
+	}
+	if count > 1 && len(runes) > 0 && runes[len(runes)-1] == 'e' {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// avgWordLengthAnalyzer reports the mean number of runes per word.
+type avgWordLengthAnalyzer struct{}
+
+func (avgWordLengthAnalyzer) Name() string { return "avg_word_length" }
+
+func (avgWordLengthAnalyzer) Analyze(sentence string) interface{} {
+	words := strings.Fields(sentence)
+	if len(words) == 0 {
+		return 0.0
+	}
+	total := 0
+	for _, w := range words {
+		total += utf8.RuneCountInString(w)
+	}
+	return float64(total) / float64(len(words))
+}
+
+// tokenCount is one entry of a freqAnalyzer result.
+type tokenCount struct {
+	Token string `json:"token"`
+	Count int    `json:"count"`
+}
+
+// freqAnalyzer reports the topN most frequent tokens (case-folded,
+// punctuation-trimmed words), most frequent first; ties keep first-seen
+// order.
+type freqAnalyzer struct {
+	topN int
+}
+
+func (freqAnalyzer) Name() string { return "freq" }
+
+func (a freqAnalyzer) Analyze(sentence string) interface{} {
+	counts := make(map[string]int)
+	var order []string
+	for _, w := range strings.Fields(sentence) {
+		token := strings.ToLower(strings.TrimFunc(w, unicode.IsPunct))
+		if token == "" {
+			continue
+		}
+		if counts[token] == 0 {
+			order = append(order, token)
+		}
+		counts[token]++
+	}
+
+	results := make([]tokenCount, 0, len(order))
+	for _, token := range order {
+		results = append(results, tokenCount{Token: token, Count: counts[token]})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Count > results[j].Count })
+	if len(results) > a.topN {
+		results = results[:a.topN]
+	}
+	return results
+}
+
+// languageAnalyzer is a stub: real language identification needs a
+// statistical model or n-gram corpus, out of scope here. It reports the
+// dominant Unicode script as a coarse proxy for the sentence's language.
+type languageAnalyzer struct{}
+
+func (languageAnalyzer) Name() string { return "language" }
+
+// scriptRanges lists the scripts languageAnalyzer recognizes, in the
+// fixed order ties are broken by: first to reach the highest count wins,
+// regardless of map-iteration order.
+var scriptRanges = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"latin", unicode.Latin},
+	{"cyrillic", unicode.Cyrillic},
+	{"greek", unicode.Greek},
+	{"han", unicode.Han},
+}
+
+func (languageAnalyzer) Analyze(sentence string) interface{} {
+	counts := make([]int, len(scriptRanges))
+	for _, r := range sentence {
+		for i, s := range scriptRanges {
+			if unicode.Is(s.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	best, bestCount := "unknown", 0
+	for i, s := range scriptRanges {
+		if counts[i] > bestCount {
+			best, bestCount = s.name, counts[i]
+		}
+	}
+	return best
+}
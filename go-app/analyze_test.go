@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestSentencesAnalyzer(t *testing.T) {
+	got := sentencesAnalyzer{}.Analyze("Wait... Really?! Yes.").(int)
+	if got != 3 {
+		t.Fatalf("got %d sentences, want 3", got)
+	}
+}
+
+func TestSyllablesAnalyzer(t *testing.T) {
+	got := syllablesAnalyzer{}.Analyze("banana apple").(int)
+	if got != 4 {
+		t.Fatalf("got %d syllables, want 4", got)
+	}
+}
+
+func TestAvgWordLengthAnalyzer(t *testing.T) {
+	got := avgWordLengthAnalyzer{}.Analyze("a bb ccc").(float64)
+	if got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+}
+
+func TestFreqAnalyzer(t *testing.T) {
+	got := freqAnalyzer{topN: 2}.Analyze("the cat sat on the mat, the cat ran.").([]tokenCount)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if got[0].Token != "the" || got[0].Count != 3 {
+		t.Fatalf("got top entry %+v, want {the 3}", got[0])
+	}
+}
+
+func TestLanguageAnalyzerDetectsScript(t *testing.T) {
+	if got := (languageAnalyzer{}).Analyze("hello world").(string); got != "latin" {
+		t.Fatalf("got %q, want latin", got)
+	}
+	if got := (languageAnalyzer{}).Analyze("привет мир").(string); got != "cyrillic" {
+		t.Fatalf("got %q, want cyrillic", got)
+	}
+}
+
+func TestRunAnalyzersReportsUnknownName(t *testing.T) {
+	results := RunAnalyzers("hello", []string{"words", "bogus"})
+	if _, ok := results["words"]; !ok {
+		t.Fatal("expected words result")
+	}
+	errResult, ok := results["bogus"].(map[string]string)
+	if !ok || errResult["error"] == "" {
+		t.Fatalf("expected error for unknown analyzer, got %+v", results["bogus"])
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the JWT claims this service expects: a legacy single "role"
+// string, an OAuth2-style "roles" list, and a space-separated "scope"
+// claim, layered on top of the registered claims (exp, iat, nbf, iss,
+// aud, ...).
+type Claims struct {
+	Role  string   `json:"role,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+	Scope string   `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// AuthConfig is the per-deployment JWT validation policy: the clock skew
+// allowed when checking iat/nbf/exp, the issuer/audience tokens must
+// present, and (optionally) how old iat may be before the token is
+// rejected outright. Issuer and Audience are only enforced when
+// non-empty; MaxIssuedAtAge is only enforced when positive.
+type AuthConfig struct {
+	ClockSkew time.Duration
+	Issuer    string
+	Audience  string
+
+	// MaxIssuedAtAge rejects tokens whose iat is older than this,
+	// independent of exp. This is deliberately a separate knob from
+	// ClockSkew: ClockSkew is a tight tolerance for clock drift between
+	// this service and the token issuer (seconds), while MaxIssuedAtAge
+	// is a much coarser "how stale can a still-unexpired token be"
+	// policy, on the order of an access token's TTL. Leave it zero to
+	// rely on exp alone, which is the right default for tokens minted by
+	// real IdPs (Auth0, Keycloak, ...) that may already be a few seconds
+	// old by the time they reach this service.
+	MaxIssuedAtAge time.Duration
+}
+
+// parserOptions builds the jwt/v5 parser options implied by cfg: iat is
+// always validated (rejecting tokens issued too far in the future, per
+// the allowed skew; jwtAuthenticator separately enforces MaxIssuedAtAge
+// for the "too far in the past" side), and iss/aud are validated when
+// configured.
+func (cfg AuthConfig) parserOptions() []jwt.ParserOption {
+	opts := []jwt.ParserOption{jwt.WithIssuedAt()}
+	if cfg.ClockSkew > 0 {
+		opts = append(opts, jwt.WithLeeway(cfg.ClockSkew))
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return opts
+}
+
+// jwtAuthenticator builds an Authenticator that verifies a Bearer JWT
+// using resolver (which may do local HS256/RS256/ES256/EdDSA verification
+// or fetch keys from a JWKS endpoint), enforces cfg's claim policy
+// (exp/nbf/iat with clock skew, iss, aud), and rejects tokens whose jti
+// has been revoked. revocation may be nil to skip the revocation check.
+func jwtAuthenticator(resolver KeyResolver, cfg AuthConfig, revocation RevocationChecker) Authenticator {
+	opts := cfg.parserOptions()
+	return func(policy Policy) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authz := r.Header.Get("Authorization")
+				if !strings.HasPrefix(authz, "Bearer ") {
+					http.Error(w, "missing bearer token", http.StatusUnauthorized)
+					return
+				}
+				tokenStr := strings.TrimPrefix(authz, "Bearer ")
+
+				var claims Claims
+				token, err := jwt.ParseWithClaims(tokenStr, &claims, resolver.ResolveKey, opts...)
+				if err != nil || !token.Valid {
+					http.Error(w, "invalid token", http.StatusUnauthorized)
+					return
+				}
+				if cfg.MaxIssuedAtAge > 0 {
+					if iat, err := claims.GetIssuedAt(); err == nil && iat != nil && time.Since(iat.Time) > cfg.MaxIssuedAtAge {
+						http.Error(w, "invalid token", http.StatusUnauthorized)
+						return
+					}
+				}
+				if revocation != nil && claims.ID != "" && revocation.IsRevoked(claims.ID) {
+					http.Error(w, "token revoked", http.StatusUnauthorized)
+					return
+				}
+				if !policy.Allows(claims) {
+					http.Error(w, "forbidden (policy not satisfied)", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, withClaims(r, claims))
+			})
+		}
+	}
+}
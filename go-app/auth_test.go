@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "unit-test-secret"
+
+func signedTestToken(t *testing.T, claims Claims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	s, err := tok.SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("signing test token: %v", err)
+	}
+	return s
+}
+
+func testRouterWithConfig(t *testing.T, cfg AuthConfig) http.Handler {
+	t.Helper()
+	resolver, err := NewKeyResolver("", "", []byte(testSecret))
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	return routes(jwtAuthenticator(resolver, cfg, nil), defaultAnalyzePolicy, nil)
+}
+
+func authRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/analyze?sentence=hello", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestAuthMiddlewareAcceptsValidToken(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			Issuer:    "test-issuer",
+			Audience:  jwt.ClaimStrings{"test-aud"},
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second, Issuer: "test-issuer", Audience: "test-aud"})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthMiddlewareRejectsExpiredToken(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute)),
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareRejectsFutureNotBefore(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now.Add(time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(2 * time.Hour)),
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareRejectsIssuedAtTooFarInFuture(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now.Add(time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareRejectsIssuedAtTooFarInPast(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second, MaxIssuedAtAge: 30 * time.Second})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+// TestAuthMiddlewareIgnoresIssuedAtAgeByDefault guards against regressing
+// to rejecting tokens solely because ClockSkew seconds have elapsed since
+// iat: MaxIssuedAtAge is a separate, opt-in knob, so a token well past
+// ClockSkew but still within exp must be accepted when MaxIssuedAtAge is
+// left at its zero value.
+func TestAuthMiddlewareIgnoresIssuedAtAgeByDefault(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthMiddlewareRejectsWrongIssuer(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			Issuer:    "someone-else",
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second, Issuer: "test-issuer"})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareRejectsWrongAudience(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			Audience:  jwt.ClaimStrings{"someone-else"},
+		},
+	}
+	router := testRouterWithConfig(t, AuthConfig{ClockSkew: 5 * time.Second, Audience: "test-aud"})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(signedTestToken(t, claims)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
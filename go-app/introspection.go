@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// introspectionMaxTTL caps how long a cached introspection result is
+// trusted, even if the token's own exp is further out.
+const introspectionMaxTTL = 10 * time.Minute
+
+// IntrospectionConfig configures RFC 7662 token introspection as an
+// alternative to local JWT verification: the bearer token is POSTed to
+// URL with ClientID/ClientSecret as HTTP Basic credentials.
+type IntrospectionConfig struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+}
+
+// introspectionResult is the subset of the RFC 7662 response this service
+// cares about.
+type introspectionResult struct {
+	Active bool   `json:"active"`
+	Scope  string `json:"scope"`
+	Sub    string `json:"sub"`
+	Exp    int64  `json:"exp"`
+}
+
+// introspectionCache caches positive introspection results in-memory,
+// keyed by a hash of the token, until the token's exp (bounded by
+// introspectionMaxTTL), to avoid an introspection call per request.
+type introspectionCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedIntrospection
+}
+
+type cachedIntrospection struct {
+	result    introspectionResult
+	expiresAt time.Time
+}
+
+func newIntrospectionCache() *introspectionCache {
+	return &introspectionCache{entries: make(map[string]cachedIntrospection)}
+}
+
+func tokenCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *introspectionCache) get(token string) (introspectionResult, bool) {
+	key := tokenCacheKey(token)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return introspectionResult{}, false
+	}
+	return entry.result, true
+}
+
+func (c *introspectionCache) put(token string, result introspectionResult) {
+	expiresAt := time.Now().Add(introspectionMaxTTL)
+	if result.Exp > 0 {
+		if tokenExp := time.Unix(result.Exp, 0); tokenExp.Before(expiresAt) {
+			expiresAt = tokenExp
+		}
+	}
+	key := tokenCacheKey(token)
+	c.mu.Lock()
+	c.entries[key] = cachedIntrospection{result: result, expiresAt: expiresAt}
+	c.mu.Unlock()
+}
+
+// introspector calls the configured introspection endpoint and caches
+// positive responses.
+type introspector struct {
+	cfg    IntrospectionConfig
+	cache  *introspectionCache
+	client *http.Client
+}
+
+func newIntrospector(cfg IntrospectionConfig) *introspector {
+	return &introspector{cfg: cfg, cache: newIntrospectionCache(), client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (i *introspector) introspect(token string) (introspectionResult, error) {
+	if cached, ok := i.cache.get(token); ok {
+		return cached, nil
+	}
+
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequest(http.MethodPost, i.cfg.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return introspectionResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(i.cfg.ClientID, i.cfg.ClientSecret)
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return introspectionResult{}, fmt.Errorf("calling introspection endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return introspectionResult{}, fmt.Errorf("introspection endpoint returned status %d", resp.StatusCode)
+	}
+
+	var result introspectionResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return introspectionResult{}, fmt.Errorf("decoding introspection response: %w", err)
+	}
+	if result.Active {
+		i.cache.put(token, result)
+	}
+	return result, nil
+}
+
+// introspectionAuthenticator builds an Authenticator that authenticates
+// requests via RFC 7662 token introspection instead of local JWT
+// verification. The introspection result is adapted into a Claims value
+// (Subject and Scope populated) so the same Policy types work for both
+// auth modes.
+func introspectionAuthenticator(i *introspector) Authenticator {
+	return func(policy Policy) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authz := r.Header.Get("Authorization")
+				if !strings.HasPrefix(authz, "Bearer ") {
+					http.Error(w, "missing bearer token", http.StatusUnauthorized)
+					return
+				}
+				token := strings.TrimPrefix(authz, "Bearer ")
+
+				result, err := i.introspect(token)
+				if err != nil || !result.Active {
+					http.Error(w, "invalid token", http.StatusUnauthorized)
+					return
+				}
+				claims := Claims{Scope: result.Scope}
+				claims.Subject = result.Sub
+				if !policy.Allows(claims) {
+					http.Error(w, "forbidden (insufficient scope)", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, withClaims(r, claims))
+			})
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func introspectionStub(t *testing.T, result introspectionResult) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "test-client" || pass != "test-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+}
+
+func TestIntrospectionMiddlewareAcceptsActiveToken(t *testing.T) {
+	srv := introspectionStub(t, introspectionResult{Active: true, Scope: "analyze:read", Sub: "alice"})
+	defer srv.Close()
+
+	i := newIntrospector(IntrospectionConfig{URL: srv.URL, ClientID: "test-client", ClientSecret: "test-secret"})
+	router := routes(introspectionAuthenticator(i), defaultAnalyzePolicy, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze?sentence=hello", nil)
+	req.Header.Set("Authorization", "Bearer opaque-token")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestIntrospectionMiddlewareRejectsInactiveToken(t *testing.T) {
+	srv := introspectionStub(t, introspectionResult{Active: false})
+	defer srv.Close()
+
+	i := newIntrospector(IntrospectionConfig{URL: srv.URL, ClientID: "test-client", ClientSecret: "test-secret"})
+	router := routes(introspectionAuthenticator(i), defaultAnalyzePolicy, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze?sentence=hello", nil)
+	req.Header.Set("Authorization", "Bearer opaque-token")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func TestIntrospectionMiddlewareRejectsMissingScope(t *testing.T) {
+	srv := introspectionStub(t, introspectionResult{Active: true, Scope: "other:scope"})
+	defer srv.Close()
+
+	i := newIntrospector(IntrospectionConfig{URL: srv.URL, ClientID: "test-client", ClientSecret: "test-secret"})
+	router := routes(introspectionAuthenticator(i), defaultAnalyzePolicy, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze?sentence=hello", nil)
+	req.Header.Set("Authorization", "Bearer opaque-token")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("got status %d, want 403", rec.Code)
+	}
+}
+
+func TestIntrospectionCachePositiveResult(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(introspectionResult{Active: true, Scope: "analyze:read"})
+	}))
+	defer srv.Close()
+
+	i := newIntrospector(IntrospectionConfig{URL: srv.URL})
+	for n := 0; n < 3; n++ {
+		if _, err := i.introspect("same-token"); err != nil {
+			t.Fatalf("introspect: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("got %d introspection calls, want 1 (cached)", calls)
+	}
+}
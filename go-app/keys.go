@@ -0,0 +1,287 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval controls how often a configured JWKS URL is re-fetched.
+const jwksRefreshInterval = 5 * time.Minute
+
+// jwksFetchTimeout bounds how long a single JWKS fetch may take, so a
+// slow or unresponsive JWKS endpoint can't hang the request-handling
+// goroutine that triggered the refresh (mirrors introspector's timeout).
+const jwksFetchTimeout = 5 * time.Second
+
+// KeyResolver resolves the verification key for a JWT and is used as the
+// keyfunc passed to jwt.ParseWithClaims. Implementations must enforce that
+// the token's signing algorithm is consistent with the key they return, to
+// guard against algorithm-confusion attacks (e.g. alg=none or HS256 against
+// an RSA/EC public key).
+type KeyResolver interface {
+	ResolveKey(token *jwt.Token) (interface{}, error)
+}
+
+// NewKeyResolver builds a KeyResolver from the service's key configuration,
+// preferring a JWKS URL, then a static PEM public key, then a symmetric
+// secret. Exactly one of these is expected to be configured in a given
+// deployment; the first non-empty one wins.
+func NewKeyResolver(jwksURL, publicKeyPEM string, secret []byte) (KeyResolver, error) {
+	if jwksURL != "" {
+		return newJWKSResolver(jwksURL), nil
+	}
+	if publicKeyPEM != "" {
+		key, err := parsePublicKeyPEM([]byte(publicKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWT_PUBLIC_KEY: %w", err)
+		}
+		return staticKeyResolver{key: key}, nil
+	}
+	if len(secret) > 0 {
+		return staticKeyResolver{key: secret}, nil
+	}
+	return nil, errors.New("no JWT key configured (set JWT_JWKS_URL, JWT_PUBLIC_KEY, or JWT_SECRET)")
+}
+
+// staticKeyResolver always resolves to the same key, used for the
+// PEM-public-key and symmetric-secret configurations.
+type staticKeyResolver struct {
+	key interface{}
+}
+
+func (r staticKeyResolver) ResolveKey(token *jwt.Token) (interface{}, error) {
+	if err := checkAlgMatchesKey(token.Method, r.key); err != nil {
+		return nil, err
+	}
+	return r.key, nil
+}
+
+// checkAlgMatchesKey rejects alg=none outright and rejects any mismatch
+// between the token's signing method family and the key's type, e.g. an
+// HS256 token presented against an RSA/EC/Ed25519 key.
+func checkAlgMatchesKey(method jwt.SigningMethod, key interface{}) error {
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if _, ok := key.([]byte); !ok {
+			return fmt.Errorf("algorithm %s is not valid for an asymmetric key", method.Alg())
+		}
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("algorithm %s requires an RSA public key", method.Alg())
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PublicKey); !ok {
+			return fmt.Errorf("algorithm %s requires an ECDSA public key", method.Alg())
+		}
+	case *jwt.SigningMethodEd25519:
+		if _, ok := key.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("algorithm %s requires an Ed25519 public key", method.Alg())
+		}
+	default:
+		return fmt.Errorf("unsupported signing method %q", method.Alg())
+	}
+	return nil
+}
+
+// parsePublicKeyPEM parses an RSA, ECDSA or Ed25519 public key from a PEM
+// block (PKIX/SubjectPublicKeyInfo form).
+func parsePublicKeyPEM(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// jwksResolver fetches and caches a JSON Web Key Set, looking up keys by
+// "kid" and refreshing the set periodically (or on a cache miss).
+type jwksResolver struct {
+	url    string
+	client *http.Client
+
+	mu      sync.RWMutex
+	keys    map[string]interface{}
+	fetched time.Time
+}
+
+func newJWKSResolver(url string) *jwksResolver {
+	return &jwksResolver{url: url, client: &http.Client{Timeout: jwksFetchTimeout}}
+}
+
+func (r *jwksResolver) ResolveKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token header has no kid")
+	}
+	key, err := r.keyForKID(kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAlgMatchesKey(token.Method, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (r *jwksResolver) keyForKID(kid string) (interface{}, error) {
+	r.mu.RLock()
+	key, ok := r.keys[kid]
+	stale := time.Since(r.fetched) > jwksRefreshInterval
+	r.mu.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := r.refresh(); err != nil {
+		if ok {
+			// Serve the last known key rather than fail the request on a
+			// transient refresh error.
+			return key, nil
+		}
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok = r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("no JWKS key for kid %q", kid)
+	}
+	return key, nil
+}
+
+type jwkSet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (r *jwksResolver) refresh() error {
+	resp, err := r.client.Get(r.url)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			log.Printf("jwks: skipping key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.fetched = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		return k.rsaPublicKey()
+	case "EC":
+		return k.ecPublicKey()
+	case "OKP":
+		return k.edPublicKey()
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decoding n: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decoding e: %w", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+func (k jsonWebKey) ecPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+	}
+	xBytes, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("decoding x: %w", err)
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("decoding y: %w", err)
+	}
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}, nil
+}
+
+func (k jsonWebKey) edPublicKey() (ed25519.PublicKey, error) {
+	xBytes, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("decoding x: %w", err)
+	}
+	if len(xBytes) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("unexpected Ed25519 key size %d", len(xBytes))
+	}
+	return ed25519.PublicKey(xBytes), nil
+}
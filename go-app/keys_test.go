@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func pemEncodePublicKey(t *testing.T, pub interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("marshaling public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func testRouterWithResolver(t *testing.T, resolver KeyResolver) http.Handler {
+	t.Helper()
+	return routes(jwtAuthenticator(resolver, AuthConfig{ClockSkew: 5 * time.Second}, nil), defaultAnalyzePolicy, nil)
+}
+
+func freshClaims() Claims {
+	now := time.Now()
+	return Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func TestStaticKeyResolverAcceptsRS256Token(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	resolver, err := NewKeyResolver("", pemEncodePublicKey(t, &priv.PublicKey), nil)
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, freshClaims()).SignedString(priv)
+	if err != nil {
+		t.Fatalf("signing RS256 token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	testRouterWithResolver(t, resolver).ServeHTTP(rec, authRequest(signed))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStaticKeyResolverAcceptsES256Token(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ECDSA key: %v", err)
+	}
+	resolver, err := NewKeyResolver("", pemEncodePublicKey(t, &priv.PublicKey), nil)
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, freshClaims()).SignedString(priv)
+	if err != nil {
+		t.Fatalf("signing ES256 token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	testRouterWithResolver(t, resolver).ServeHTTP(rec, authRequest(signed))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStaticKeyResolverAcceptsEdDSAToken(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating Ed25519 key: %v", err)
+	}
+	resolver, err := NewKeyResolver("", pemEncodePublicKey(t, pub), nil)
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, freshClaims()).SignedString(priv)
+	if err != nil {
+		t.Fatalf("signing EdDSA token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	testRouterWithResolver(t, resolver).ServeHTTP(rec, authRequest(signed))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStaticKeyResolverRejectsHS256AlgConfusion(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	resolver, err := NewKeyResolver("", pemEncodePublicKey(t, &priv.PublicKey), nil)
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+
+	// Classic algorithm-confusion attack: sign with HS256 using the
+	// RSA public key's DER bytes as the HMAC secret, hoping a verifier
+	// that trusts the token's own "alg" header will check it with the
+	// same bytes it uses as an RSA public key.
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("marshaling public key: %v", err)
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, freshClaims()).SignedString(der)
+	if err != nil {
+		t.Fatalf("signing HS256 token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	testRouterWithResolver(t, resolver).ServeHTTP(rec, authRequest(signed))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func TestStaticKeyResolverRejectsNoneAlg(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	resolver, err := NewKeyResolver("", pemEncodePublicKey(t, &priv.PublicKey), nil)
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, freshClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("signing alg=none token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	testRouterWithResolver(t, resolver).ServeHTTP(rec, authRequest(signed))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func TestJWKSResolverFetchesKeyByKID(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jsonWebKey{rsaJWK("key-1", &priv.PublicKey)}})
+	}))
+	defer srv.Close()
+
+	resolver, err := NewKeyResolver(srv.URL, "", nil)
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, freshClaims())
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(priv)
+	if err != nil {
+		t.Fatalf("signing RS256 token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	testRouterWithResolver(t, resolver).ServeHTTP(rec, authRequest(signed))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestJWKSResolverRejectsUnknownKID(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jsonWebKey{rsaJWK("key-1", &priv.PublicKey)}})
+	}))
+	defer srv.Close()
+
+	resolver, err := NewKeyResolver(srv.URL, "", nil)
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, freshClaims())
+	token.Header["kid"] = "unknown-key"
+	signed, err := token.SignedString(priv)
+	if err != nil {
+		t.Fatalf("signing RS256 token: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	testRouterWithResolver(t, resolver).ServeHTTP(rec, authRequest(signed))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
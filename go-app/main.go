@@ -2,48 +2,151 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
-	"unicode"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// defaultClockSkew bounds how far a token's iat/nbf/exp may drift from the
+// server's clock, matching the tight window engine-API style JWT
+// verifiers use (e.g. go-ethereum's ±5s window on iat/nbf/exp).
+const defaultClockSkew = 5 * time.Second
+
+// defaultMaxIssuedAtAge is unset (disabled) by default: unlike
+// ClockSkew, which only tolerates clock drift of a few seconds, bounding
+// how old iat may be would reject otherwise-valid, unexpired tokens
+// minted by real IdPs that are already a little stale by the time they
+// reach this service. Deployments that want this belt-and-suspenders
+// check can opt in with JWT_MAX_ISSUED_AT_AGE_SECONDS, sized to roughly
+// the issuer's access token TTL.
+const defaultMaxIssuedAtAge = 0
+
+// defaultAnalyzerNames is used when a request doesn't specify which
+// analyzers to run, e.g. the legacy GET ?sentence= form.
+var defaultAnalyzerNames = []string{"words", "vowels"}
+
 type AnalyzeRequest struct {
-	Sentence string `json:"sentence"`
+	Sentence  string   `json:"sentence"`
+	Analyzers []string `json:"analyzers,omitempty"`
 }
 
 type AnalyzeResponse struct {
-	Words      int    `json:"words"`
-	Vowels     int    `json:"vowels"`
-	Consonants int    `json:"consonants"`
-	Sentence   string `json:"sentence,omitempty"`
-}
-
-// Custom JWT claims with a simple role string.
-type Claims struct {
-	Role string `json:"role"`
-	jwt.RegisteredClaims
+	Sentence string                 `json:"sentence,omitempty"`
+	Results  map[string]interface{} `json:"results"`
 }
 
 func main() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("JWT_SECRET is not set (refuse to start without auth secret)")
+	store := newMemoryTokenStore()
+
+	analyzeAuth, err := buildAnalyzeAuth(store)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	analyzePolicy, err := buildAnalyzePolicy()
+	if err != nil {
+		log.Fatalf("invalid ANALYZE_POLICY: %v", err)
+	}
+
+	issuer, err := buildTokenIssuer(store)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if issuer == nil {
+		log.Printf("POST /token disabled (no JWT_SECRET or no users configured)")
 	}
 
 	addr := ":8080"
 	log.Printf("listening on %s", addr)
-	if err := http.ListenAndServe(addr, routes([]byte(secret))); err != nil {
+	if err := http.ListenAndServe(addr, routes(analyzeAuth, analyzePolicy, issuer)); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func routes(secret []byte) http.Handler {
+// defaultAnalyzePolicy is the access policy for /analyze when
+// ANALYZE_POLICY isn't set: either the legacy "user"/"admin" role (local
+// JWT mode) or an analyze:* scope (OAuth2 introspection mode) is
+// sufficient.
+var defaultAnalyzePolicy = Any(Role("user", "admin"), ScopeAny("analyze:read", "analyze:write"))
+
+// buildAnalyzePolicy resolves the access policy for /analyze: if
+// ANALYZE_POLICY is set, it's parsed as a ParsePolicy expression (e.g.
+// "scope:analyze:read OR role:admin"), letting a deployment express a
+// custom policy without a code change; otherwise defaultAnalyzePolicy
+// applies.
+func buildAnalyzePolicy() (Policy, error) {
+	expr := os.Getenv("ANALYZE_POLICY")
+	if expr == "" {
+		return defaultAnalyzePolicy, nil
+	}
+	return ParsePolicy(expr)
+}
+
+// buildAnalyzeAuth selects and configures the Authenticator for /analyze:
+// RFC 7662 OAuth2 token introspection if OAUTH2_INTROSPECTION_URL is set,
+// otherwise local JWT verification (which also rejects tokens revoked
+// through POST /token/revoke).
+func buildAnalyzeAuth(revocation RevocationChecker) (Authenticator, error) {
+	if introspectionURL := os.Getenv("OAUTH2_INTROSPECTION_URL"); introspectionURL != "" {
+		cfg := IntrospectionConfig{
+			URL:          introspectionURL,
+			ClientID:     os.Getenv("OAUTH2_CLIENT_ID"),
+			ClientSecret: os.Getenv("OAUTH2_CLIENT_SECRET"),
+		}
+		return introspectionAuthenticator(newIntrospector(cfg)), nil
+	}
+
+	resolver, err := NewKeyResolver(os.Getenv("JWT_JWKS_URL"), os.Getenv("JWT_PUBLIC_KEY"), []byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return nil, err
+	}
+	cfg := AuthConfig{
+		ClockSkew:      defaultClockSkew,
+		Issuer:         os.Getenv("JWT_ISSUER"),
+		Audience:       os.Getenv("JWT_AUDIENCE"),
+		MaxIssuedAtAge: defaultMaxIssuedAtAge,
+	}
+	if v := os.Getenv("JWT_CLOCK_SKEW_SECONDS"); v != "" {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_CLOCK_SKEW_SECONDS: %w", err)
+		}
+		cfg.ClockSkew = time.Duration(secs) * time.Second
+	}
+	if v := os.Getenv("JWT_MAX_ISSUED_AT_AGE_SECONDS"); v != "" {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_MAX_ISSUED_AT_AGE_SECONDS: %w", err)
+		}
+		cfg.MaxIssuedAtAge = time.Duration(secs) * time.Second
+	}
+	return jwtAuthenticator(resolver, cfg, revocation), nil
+}
+
+// buildTokenIssuer wires up POST /token and friends. It returns a nil
+// issuer (and no error) when the deployment hasn't configured a symmetric
+// JWT_SECRET or any users, since those endpoints only make sense when
+// this service mints its own tokens.
+func buildTokenIssuer(store TokenStore) (*TokenIssuer, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+	users, err := loadUsersFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("loading users: %w", err)
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return NewTokenIssuer([]byte(secret), newStaticUserStore(users), store, os.Getenv("JWT_ISSUER"), os.Getenv("JWT_AUDIENCE")), nil
+}
+
+func routes(analyzeAuth Authenticator, policy Policy, issuer *TokenIssuer) http.Handler {
 	mux := http.NewServeMux()
 
 	// No auth for health
@@ -52,9 +155,10 @@ func routes(secret []byte) http.Handler {
 		w.Write([]byte("ok"))
 	})
 
-	// Require auth (role: user or admin)
+	// Guarded by policy, below.
 	analyze := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var sentence string
+		names := defaultAnalyzerNames
 		switch r.Method {
 		case http.MethodGet:
 			sentence = r.URL.Query().Get("sentence")
@@ -62,6 +166,9 @@ func routes(secret []byte) http.Handler {
 				http.Error(w, "missing 'sentence' query", http.StatusBadRequest)
 				return
 			}
+			if raw := r.URL.Query().Get("analyzers"); raw != "" {
+				names = strings.Split(raw, ",")
+			}
 		case http.MethodPost:
 			var req AnalyzeRequest
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -69,77 +176,27 @@ func routes(secret []byte) http.Handler {
 				return
 			}
 			sentence = req.Sentence
+			if len(req.Analyzers) > 0 {
+				names = req.Analyzers
+			}
 		default:
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		words, vowels, consonants := Analyze(sentence)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(AnalyzeResponse{
-			Words:      words,
-			Vowels:     vowels,
-			Consonants: consonants,
-			Sentence:   sentence,
+			Sentence: sentence,
+			Results:  RunAnalyzers(sentence, names),
 		})
 	})
-	mux.Handle("/analyze", authMiddleware(secret, "user", "admin")(analyze))
+	Route(mux, "/analyze", analyzeAuth, analyze).Requires(policy)
 
-	return mux
-}
-
-// authMiddleware verifies a Bearer JWT and enforces that the "role" claim is in allowedRoles.
-func authMiddleware(secret []byte, allowedRoles ...string) func(http.Handler) http.Handler {
-	allowed := make(map[string]struct{}, len(allowedRoles))
-	for _, r := range allowedRoles {
-		allowed[r] = struct{}{}
+	if issuer != nil {
+		mux.HandleFunc("/token", issuer.HandleLogin)
+		mux.HandleFunc("/token/refresh", issuer.HandleRefresh)
+		mux.HandleFunc("/token/revoke", issuer.HandleRevoke)
 	}
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authz := r.Header.Get("Authorization")
-			if !strings.HasPrefix(authz, "Bearer ") {
-				http.Error(w, "missing bearer token", http.StatusUnauthorized)
-				return
-			}
-			tokenStr := strings.TrimPrefix(authz, "Bearer ")
-
-			var claims Claims
-			token, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
-				if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-					return nil, errors.New("unexpected signing method")
-				}
-				return secret, nil
-			})
-			if err != nil || !token.Valid {
-				http.Error(w, "invalid token", http.StatusUnauthorized)
-				return
-			}
-			if claims.ExpiresAt != nil && time.Now().After(claims.ExpiresAt.Time) {
-				http.Error(w, "token expired", http.StatusUnauthorized)
-				return
-			}
-			if _, ok := allowed[claims.Role]; !ok {
-				http.Error(w, "forbidden (insufficient role)", http.StatusForbidden)
-				return
-			}
-			next.ServeHTTP(w, r)
-		})
-	}
-}
 
-// Analyze returns number of words, vowels and consonants in a sentence.
-func Analyze(s string) (words int, vowels int, consonants int) {
-	words = len(strings.Fields(s))
-	for _, r := range s {
-		if !unicode.IsLetter(r) {
-			continue
-		}
-		switch unicode.ToLower(r) {
-		case 'a', 'e', 'i', 'o', 'u':
-			vowels++
-		default:
-			consonants++
-		}
-	}
-	return
+	return mux
 }
@@ -1,31 +1,128 @@
 package main
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func wordVowelConsonantCounts(s string) (words, vowels, consonants int) {
+	words = wordsAnalyzer{}.Analyze(s).(int)
+	vc := vowelsAnalyzer{}.Analyze(s).(map[string]int)
+	return words, vc["vowels"], vc["consonants"]
+}
 
 func TestAnalyzeBasic(t *testing.T) {
-	w, v, c := Analyze("Hello, world!")
+	w, v, c := wordVowelConsonantCounts("Hello, world!")
 	if w != 2 || v != 3 || c != 7 {
 		t.Fatalf("got (w=%d, v=%d, c=%d); want (2,3,7)", w, v, c)
 	}
 }
 
 func TestAnalyzeEmpty(t *testing.T) {
-	w, v, c := Analyze("")
+	w, v, c := wordVowelConsonantCounts("")
 	if w != 0 || v != 0 || c != 0 {
 		t.Fatalf("got (w=%d, v=%d, c=%d); want (0,0,0)", w, v, c)
 	}
 }
 
 func TestAnalyzeVowelsOnly(t *testing.T) {
-	w, v, c := Analyze("a e i o u")
+	w, v, c := wordVowelConsonantCounts("a e i o u")
 	if w != 5 || v != 5 || c != 0 {
 		t.Fatalf("got (w=%d, v=%d, c=%d); want (5,5,0)", w, v, c)
 	}
 }
 
 func TestAnalyzeConsonantsOnly(t *testing.T) {
-	w, v, c := Analyze("rhythm")
+	w, v, c := wordVowelConsonantCounts("rhythm")
 	if w != 1 || v != 0 || c != 6 {
 		t.Fatalf("got (w=%d, v=%d, c=%d); want (1,0,6)", w, v, c)
 	}
 }
+
+func TestAnalyzeAccentedVowels(t *testing.T) {
+	w, v, c := wordVowelConsonantCounts("café über")
+	if w != 2 || v != 4 || c != 4 {
+		t.Fatalf("got (w=%d, v=%d, c=%d); want (2,4,4)", w, v, c)
+	}
+}
+
+func TestBuildAnalyzePolicyDefaultsWithoutEnv(t *testing.T) {
+	p, err := buildAnalyzePolicy()
+	if err != nil {
+		t.Fatalf("buildAnalyzePolicy: %v", err)
+	}
+	if !p.Allows(Claims{Role: "user"}) {
+		t.Fatal("expected default policy to allow role:user")
+	}
+	if p.Allows(Claims{Role: "guest"}) {
+		t.Fatal("expected default policy to deny role:guest")
+	}
+}
+
+func TestBuildAnalyzePolicyParsesEnvOverride(t *testing.T) {
+	t.Setenv("ANALYZE_POLICY", "role:admin")
+	p, err := buildAnalyzePolicy()
+	if err != nil {
+		t.Fatalf("buildAnalyzePolicy: %v", err)
+	}
+	if !p.Allows(Claims{Role: "admin"}) {
+		t.Fatal("expected ANALYZE_POLICY override to allow role:admin")
+	}
+	if p.Allows(Claims{Role: "user"}) {
+		t.Fatal("expected ANALYZE_POLICY override to deny role:user (no longer in the default policy)")
+	}
+}
+
+func TestBuildAnalyzePolicyRejectsInvalidExpression(t *testing.T) {
+	t.Setenv("ANALYZE_POLICY", "AND role:user")
+	if _, err := buildAnalyzePolicy(); err == nil {
+		t.Fatal("expected error for invalid ANALYZE_POLICY expression")
+	}
+}
+
+// TestAnalyzeRouteHonorsAnalyzePolicyOverride is an end-to-end check that
+// ANALYZE_POLICY actually reaches the /analyze route, not just
+// buildAnalyzePolicy in isolation.
+func TestAnalyzeRouteHonorsAnalyzePolicyOverride(t *testing.T) {
+	t.Setenv("ANALYZE_POLICY", "role:admin")
+	policy, err := buildAnalyzePolicy()
+	if err != nil {
+		t.Fatalf("buildAnalyzePolicy: %v", err)
+	}
+	resolver, err := NewKeyResolver("", "", []byte(testSecret))
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	router := routes(jwtAuthenticator(resolver, AuthConfig{ClockSkew: 5 * time.Second}, nil), policy, nil)
+
+	now := time.Now()
+	userToken := signedTestToken(t, Claims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(userToken))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("role:user got status %d, want 403 under ANALYZE_POLICY=role:admin", rec.Code)
+	}
+
+	adminToken := signedTestToken(t, Claims{
+		Role: "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, authRequest(adminToken))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("role:admin got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// claimsContextKey is the context key under which an Authenticator
+// stashes the parsed Claims for downstream handlers.
+type claimsContextKey struct{}
+
+// RequestClaims returns the Claims an Authenticator stashed on r's
+// context, so handlers can read sub/scope/role without re-parsing the
+// bearer token.
+func RequestClaims(r *http.Request) (Claims, bool) {
+	claims, ok := r.Context().Value(claimsContextKey{}).(Claims)
+	return claims, ok
+}
+
+func withClaims(r *http.Request, claims Claims) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims))
+}
+
+// Policy decides whether a set of claims satisfies a route's access
+// requirements.
+type Policy interface {
+	Allows(claims Claims) bool
+}
+
+// PolicyFunc adapts a plain function to a Policy.
+type PolicyFunc func(claims Claims) bool
+
+func (f PolicyFunc) Allows(claims Claims) bool { return f(claims) }
+
+// Role requires the claims' "role" to be one of the given roles.
+func Role(roles ...string) Policy {
+	set := stringSet(roles)
+	return PolicyFunc(func(claims Claims) bool {
+		_, ok := set[claims.Role]
+		return ok
+	})
+}
+
+// RolesAny requires the claims' "roles" claim to contain at least one of
+// the given roles.
+func RolesAny(roles ...string) Policy {
+	set := stringSet(roles)
+	return PolicyFunc(func(claims Claims) bool {
+		for _, r := range claims.Roles {
+			if _, ok := set[r]; ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ScopeAny requires the claims' space-separated "scope" claim to contain
+// at least one of the given scopes.
+func ScopeAny(scopes ...string) Policy {
+	set := stringSet(scopes)
+	return PolicyFunc(func(claims Claims) bool {
+		for _, s := range strings.Fields(claims.Scope) {
+			if _, ok := set[s]; ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// All requires every given policy to allow the claims.
+func All(policies ...Policy) Policy {
+	return PolicyFunc(func(claims Claims) bool {
+		for _, p := range policies {
+			if !p.Allows(claims) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Any requires at least one given policy to allow the claims.
+func Any(policies ...Policy) Policy {
+	return PolicyFunc(func(claims Claims) bool {
+		for _, p := range policies {
+			if p.Allows(claims) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func stringSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// ParsePolicy parses a simple policy expression such as
+// "scope:analyze:read AND role:user" or "scope:a OR scope:b" into a
+// Policy. Each term is "scope:<name>", "role:<name>", or "roles:<name>";
+// a single logical operator (AND or OR) applies across the whole
+// expression.
+func ParsePolicy(expr string) (Policy, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("empty policy expression")
+	}
+
+	op := "AND"
+	var terms []string
+	fields := strings.Fields(expr)
+	for i, f := range fields {
+		switch strings.ToUpper(f) {
+		case "AND", "OR":
+			if i == 0 || i == len(fields)-1 {
+				return nil, fmt.Errorf("dangling operator in policy expression %q", expr)
+			}
+			op = strings.ToUpper(f)
+		default:
+			terms = append(terms, f)
+		}
+	}
+
+	policies := make([]Policy, 0, len(terms))
+	for _, term := range terms {
+		p, err := parsePolicyTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	if op == "OR" {
+		return Any(policies...), nil
+	}
+	return All(policies...), nil
+}
+
+func parsePolicyTerm(term string) (Policy, error) {
+	kind, value, ok := strings.Cut(term, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid policy term %q (want kind:value)", term)
+	}
+	switch kind {
+	case "scope":
+		return ScopeAny(value), nil
+	case "role":
+		return Role(value), nil
+	case "roles":
+		return RolesAny(value), nil
+	default:
+		return nil, fmt.Errorf("unknown policy term kind %q", kind)
+	}
+}
+
+// Authenticator verifies a bearer token (by whatever means: local JWT
+// verification, OAuth2 introspection, ...) and, given a route's Policy,
+// returns middleware that stashes the resulting Claims on the request
+// context and enforces the policy.
+type Authenticator func(policy Policy) func(http.Handler) http.Handler
+
+// RouteBuilder attaches an authorization Policy to a single route,
+// deferring registration on mux until Requires is called, e.g.:
+//
+//	Route(mux, "/analyze", analyzeAuth, analyzeHandler).
+//		Requires(ScopeAny("analyze:read", "analyze:write"))
+type RouteBuilder struct {
+	mux     *http.ServeMux
+	pattern string
+	auth    Authenticator
+	handler http.Handler
+}
+
+// Route begins registering pattern on mux, to be authenticated by auth
+// and served by handler once a Policy is attached via Requires.
+func Route(mux *http.ServeMux, pattern string, auth Authenticator, handler http.Handler) *RouteBuilder {
+	return &RouteBuilder{mux: mux, pattern: pattern, auth: auth, handler: handler}
+}
+
+// Requires finishes registering the route, guarding it with policy.
+func (b *RouteBuilder) Requires(policy Policy) {
+	b.mux.Handle(b.pattern, b.auth(policy)(b.handler))
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestScopeAnyPolicy(t *testing.T) {
+	p := ScopeAny("analyze:read", "analyze:write")
+	if !p.Allows(Claims{Scope: "other analyze:write"}) {
+		t.Fatal("expected scope match to allow")
+	}
+	if p.Allows(Claims{Scope: "other:scope"}) {
+		t.Fatal("expected no scope match to deny")
+	}
+}
+
+func TestRolesAnyPolicy(t *testing.T) {
+	p := RolesAny("admin")
+	if !p.Allows(Claims{Roles: []string{"editor", "admin"}}) {
+		t.Fatal("expected roles match to allow")
+	}
+	if p.Allows(Claims{Roles: []string{"editor"}}) {
+		t.Fatal("expected no roles match to deny")
+	}
+}
+
+func TestParsePolicyAnd(t *testing.T) {
+	p, err := ParsePolicy("scope:analyze:read AND role:user")
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	if !p.Allows(Claims{Role: "user", Scope: "analyze:read"}) {
+		t.Fatal("expected both conditions met to allow")
+	}
+	if p.Allows(Claims{Role: "user", Scope: "other"}) {
+		t.Fatal("expected missing scope to deny")
+	}
+}
+
+func TestParsePolicyOr(t *testing.T) {
+	p, err := ParsePolicy("role:admin OR scope:analyze:write")
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	if !p.Allows(Claims{Scope: "analyze:write"}) {
+		t.Fatal("expected either condition to allow")
+	}
+	if p.Allows(Claims{Role: "user", Scope: "other"}) {
+		t.Fatal("expected neither condition to deny")
+	}
+}
+
+func TestParsePolicyRejectsDanglingOperator(t *testing.T) {
+	if _, err := ParsePolicy("AND role:user"); err == nil {
+		t.Fatal("expected error for dangling operator")
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultAccessTokenTTL relies on /analyze's AuthConfig.MaxIssuedAtAge
+// being left at its default of zero (disabled): that knob bounds how
+// stale an unexpired token's iat may be, and if it were ever defaulted
+// to something shorter than defaultAccessTokenTTL, tokens minted here
+// would stop working well before exp. See TestTokenAccessTokenUsableAfterRealisticDelay.
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// TokenIssuer mints and manages access/refresh tokens for POST /token,
+// POST /token/refresh and POST /token/revoke. It signs access tokens with
+// the service's own symmetric secret, independent of whatever KeyResolver
+// /analyze uses to verify them.
+type TokenIssuer struct {
+	secret     []byte
+	users      UserStore
+	store      TokenStore
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenIssuer builds a TokenIssuer using the default access/refresh
+// token lifetimes.
+func NewTokenIssuer(secret []byte, users UserStore, store TokenStore, issuer, audience string) *TokenIssuer {
+	return &TokenIssuer{
+		secret:     secret,
+		users:      users,
+		store:      store,
+		issuer:     issuer,
+		audience:   audience,
+		accessTTL:  defaultAccessTokenTTL,
+		refreshTTL: defaultRefreshTokenTTL,
+	}
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (i *TokenIssuer) mintAccessToken(username, role string) (string, error) {
+	now := time.Now()
+	jti, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(i.accessTTL)),
+			ID:        jti,
+		},
+	}
+	if i.issuer != "" {
+		claims.Issuer = i.issuer
+	}
+	if i.audience != "" {
+		claims.Audience = jwt.ClaimStrings{i.audience}
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+}
+
+// issue mints a fresh access/refresh token pair for username/role and
+// writes it to w.
+func (i *TokenIssuer) issue(w http.ResponseWriter, username, role string) {
+	access, err := i.mintAccessToken(username, role)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	refresh, err := randomToken()
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	if err := i.store.SaveRefreshToken(RefreshToken{
+		Token:     refresh,
+		Username:  username,
+		Role:      role,
+		ExpiresAt: time.Now().Add(i.refreshTTL),
+	}); err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(i.accessTTL.Seconds()),
+		TokenType:    "Bearer",
+	})
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// HandleLogin implements POST /token: exchanges a username/password for
+// an access + refresh token pair.
+func (i *TokenIssuer) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	user, err := i.users.Authenticate(req.Username, req.Password)
+	if err != nil {
+		http.Error(w, "invalid username or password", http.StatusUnauthorized)
+		return
+	}
+	i.issue(w, user.Username, user.Role)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// HandleRefresh implements POST /token/refresh: exchanges a single-use
+// refresh token for a new access + refresh token pair.
+func (i *TokenIssuer) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	rt, ok := i.store.TakeRefreshToken(req.RefreshToken)
+	if !ok {
+		http.Error(w, "invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+	i.issue(w, rt.Username, rt.Role)
+}
+
+type revokeRequest struct {
+	Token string `json:"token"`
+}
+
+// HandleRevoke implements POST /token/revoke (RFC 7009). Per the RFC,
+// revoking an invalid or already-revoked token is not an error.
+func (i *TokenIssuer) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req revokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := i.store.TakeRefreshToken(req.Token); ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	var claims Claims
+	_, err := parser.ParseWithClaims(req.Token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return i.secret, nil
+	})
+	if err == nil && claims.ID != "" {
+		expiresAt := time.Now().Add(i.refreshTTL)
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		i.store.RevokeJTI(claims.ID, expiresAt)
+	}
+	w.WriteHeader(http.StatusOK)
+}
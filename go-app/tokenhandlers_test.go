@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testTokenIssuer(t *testing.T) (*TokenIssuer, *memoryTokenStore) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %v", err)
+	}
+	store := newMemoryTokenStore()
+	users := newStaticUserStore([]User{{Username: "alice", PasswordHash: string(hash), Role: "user"}})
+	return NewTokenIssuer([]byte(testSecret), users, store, "", ""), store
+}
+
+func postJSON(t *testing.T, handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestTokenLoginAndRefresh(t *testing.T) {
+	issuer, _ := testTokenIssuer(t)
+
+	rec := postJSON(t, issuer.HandleLogin, loginRequest{Username: "alice", Password: "hunter2"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: got status %d: %s", rec.Code, rec.Body.String())
+	}
+	var tok tokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &tok); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if tok.AccessToken == "" || tok.RefreshToken == "" || tok.TokenType != "Bearer" {
+		t.Fatalf("incomplete token response: %+v", tok)
+	}
+
+	rec = postJSON(t, issuer.HandleRefresh, refreshRequest{RefreshToken: tok.RefreshToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: got status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The refresh token is single-use.
+	rec = postJSON(t, issuer.HandleRefresh, refreshRequest{RefreshToken: tok.RefreshToken})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token: got status %d, want 401", rec.Code)
+	}
+}
+
+func TestTokenLoginRejectsBadPassword(t *testing.T) {
+	issuer, _ := testTokenIssuer(t)
+
+	rec := postJSON(t, issuer.HandleLogin, loginRequest{Username: "alice", Password: "wrong"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", rec.Code)
+	}
+}
+
+func TestTokenRevokeBlocksAccess(t *testing.T) {
+	issuer, store := testTokenIssuer(t)
+
+	rec := postJSON(t, issuer.HandleLogin, loginRequest{Username: "alice", Password: "hunter2"})
+	var tok tokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &tok); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	rec = postJSON(t, issuer.HandleRevoke, revokeRequest{Token: tok.AccessToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke: got status %d", rec.Code)
+	}
+
+	resolver, err := NewKeyResolver("", "", []byte(testSecret))
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	router := routes(jwtAuthenticator(resolver, AuthConfig{ClockSkew: defaultClockSkew}, store), defaultAnalyzePolicy, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze?sentence=hello", nil)
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	recAnalyze := httptest.NewRecorder()
+	router.ServeHTTP(recAnalyze, req)
+	if recAnalyze.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401 (revoked)", recAnalyze.Code)
+	}
+}
+
+// TestTokenAccessTokenUsableAfterRealisticDelay mints a real
+// TokenIssuer access token (15-minute TTL) and presents it after the
+// iat is older than defaultClockSkew, the same AuthConfig /analyze runs
+// behind in main.go. MaxIssuedAtAge defaults to zero (disabled), so the
+// token must still be accepted on exp alone, not rejected just because
+// more than ClockSkew seconds have passed since it was issued.
+func TestTokenAccessTokenUsableAfterRealisticDelay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping real-delay test in -short mode")
+	}
+	issuer, store := testTokenIssuer(t)
+
+	rec := postJSON(t, issuer.HandleLogin, loginRequest{Username: "alice", Password: "hunter2"})
+	var tok tokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &tok); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	time.Sleep(defaultClockSkew + time.Second)
+
+	resolver, err := NewKeyResolver("", "", []byte(testSecret))
+	if err != nil {
+		t.Fatalf("NewKeyResolver: %v", err)
+	}
+	router := routes(jwtAuthenticator(resolver, AuthConfig{ClockSkew: defaultClockSkew}, store), defaultAnalyzePolicy, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze?sentence=hello", nil)
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	recAnalyze := httptest.NewRecorder()
+	router.ServeHTTP(recAnalyze, req)
+	if recAnalyze.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200 (token has 14+ minutes left before exp): %s", recAnalyze.Code, recAnalyze.Body.String())
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// RefreshToken is a persisted refresh token record.
+type RefreshToken struct {
+	Token     string
+	Username  string
+	Role      string
+	ExpiresAt time.Time
+}
+
+// RevocationChecker reports whether an access token's jti has been
+// revoked. TokenStore satisfies this so jwtAuthenticator can reject
+// revoked tokens without depending on the rest of the token store API.
+type RevocationChecker interface {
+	IsRevoked(jti string) bool
+}
+
+// TokenStore persists refresh tokens and revoked access-token jtis. The
+// in-memory implementation below is the default; a Redis- or
+// BoltDB-backed store can implement the same interface for multi-instance
+// deployments.
+type TokenStore interface {
+	RevocationChecker
+	SaveRefreshToken(rt RefreshToken) error
+	// TakeRefreshToken consumes (single-use) a refresh token. ok is false
+	// if the token is unknown, already used, or expired.
+	TakeRefreshToken(token string) (rt RefreshToken, ok bool)
+	RevokeJTI(jti string, expiresAt time.Time)
+}
+
+// memoryTokenStore is the default in-memory TokenStore. It is safe for
+// concurrent use but does not persist across restarts.
+type memoryTokenStore struct {
+	mu      sync.Mutex
+	refresh map[string]RefreshToken
+	revoked map[string]time.Time
+}
+
+func newMemoryTokenStore() *memoryTokenStore {
+	return &memoryTokenStore{
+		refresh: make(map[string]RefreshToken),
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryTokenStore) SaveRefreshToken(rt RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh[rt.Token] = rt
+	return nil
+}
+
+func (s *memoryTokenStore) TakeRefreshToken(token string) (RefreshToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.refresh[token]
+	if !ok {
+		return RefreshToken{}, false
+	}
+	delete(s.refresh, token)
+	if time.Now().After(rt.ExpiresAt) {
+		return RefreshToken{}, false
+	}
+	return rt, true
+}
+
+func (s *memoryTokenStore) RevokeJTI(jti string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+	for id, exp := range s.revoked {
+		if time.Now().After(exp) {
+			delete(s.revoked, id)
+		}
+	}
+}
+
+func (s *memoryTokenStore) IsRevoked(jti string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.revoked[jti]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiresAt) {
+		delete(s.revoked, jti)
+		return false
+	}
+	return true
+}
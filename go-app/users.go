@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User is a single authenticable account for the POST /token endpoint.
+type User struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"` // bcrypt
+	Role         string `json:"role"`
+}
+
+// ErrInvalidCredentials is returned by UserStore.Authenticate when the
+// username is unknown or the password does not match.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// UserStore authenticates username/password pairs. Implementations are
+// pluggable; staticUserStore below covers the file/env-configured cases
+// this service starts with.
+type UserStore interface {
+	Authenticate(username, password string) (User, error)
+}
+
+// staticUserStore holds a fixed, in-memory set of users loaded once at
+// startup from a JSON file or individual env vars.
+type staticUserStore struct {
+	byUsername map[string]User
+}
+
+func newStaticUserStore(users []User) *staticUserStore {
+	byUsername := make(map[string]User, len(users))
+	for _, u := range users {
+		byUsername[u.Username] = u
+	}
+	return &staticUserStore{byUsername: byUsername}
+}
+
+func (s *staticUserStore) Authenticate(username, password string) (User, error) {
+	u, ok := s.byUsername[username]
+	if !ok {
+		return User{}, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+// loadUsersFromEnv builds the static user list from AUTH_USERS_FILE (a
+// JSON array of User) if set, otherwise from an AUTH_ADMIN_USERNAME /
+// AUTH_ADMIN_PASSWORD_HASH and AUTH_USER_USERNAME / AUTH_USER_PASSWORD_HASH
+// pair of env vars. Returns an empty slice if none are configured.
+func loadUsersFromEnv() ([]User, error) {
+	if path := os.Getenv("AUTH_USERS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var users []User
+		if err := json.Unmarshal(data, &users); err != nil {
+			return nil, err
+		}
+		return users, nil
+	}
+
+	var users []User
+	if username, hash := os.Getenv("AUTH_ADMIN_USERNAME"), os.Getenv("AUTH_ADMIN_PASSWORD_HASH"); username != "" && hash != "" {
+		users = append(users, User{Username: username, PasswordHash: hash, Role: "admin"})
+	}
+	if username, hash := os.Getenv("AUTH_USER_USERNAME"), os.Getenv("AUTH_USER_PASSWORD_HASH"); username != "" && hash != "" {
+		users = append(users, User{Username: username, PasswordHash: hash, Role: "user"})
+	}
+	return users, nil
+}